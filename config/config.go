@@ -0,0 +1,47 @@
+// Package config holds per-server scan configuration.
+package config
+
+// ServerInfo holds the scan configuration and per-host state for a single
+// target server.
+type ServerInfo struct {
+	ServerName string
+	Host       string
+	Port       string
+
+	IPv4Addrs []string
+	IPv6Addrs []string
+
+	// ControlMaster reports whether Port's SSH connection is multiplexed
+	// over a controlling master, in which case opening additional
+	// connections is effectively free.
+	ControlMaster bool
+
+	// ScanConcurrency bounds the worker pool size used by scanners that can
+	// fan work out across multiple connections (local scans, and SSH scans
+	// multiplexed over a ControlMaster). Zero means "use runtime.NumCPU()".
+	ScanConcurrency int
+
+	// IgnoreUnknowns suppresses models.Unknown reporting for hosts where
+	// coverage gaps (e.g. a version that could not be extracted) are
+	// expected and not actionable.
+	IgnoreUnknowns bool
+
+	MacOS MacOSConf
+}
+
+// MacOSConf holds macOS-specific scan toggles and options.
+type MacOSConf struct {
+	// ScanHomebrew, ScanHomebrewCask, ScanMacPorts, and ScanMacAppStore
+	// toggle the corresponding third-party package manager scanners in
+	// scanner.scanThirdPartyPackages. Each defaults to false so a host
+	// without a given manager isn't probed unnecessarily.
+	ScanHomebrew     bool
+	ScanHomebrewCask bool
+	ScanMacPorts     bool
+	ScanMacAppStore  bool
+
+	// LibraryScanRoots overrides the directories scanner.scanLibraries walks
+	// for language-ecosystem lockfiles. Empty means use the built-in
+	// defaults.
+	LibraryScanRoots []string
+}
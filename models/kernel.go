@@ -0,0 +1,7 @@
+package models
+
+// Kernel holds the running kernel's release and version strings.
+type Kernel struct {
+	Release string
+	Version string
+}
@@ -0,0 +1,11 @@
+package models
+
+// LibraryScanner detects packages managed by a language-ecosystem package
+// manager (npm, pip, gem, cargo, ...) from a single lockfile.
+type LibraryScanner interface {
+	GetLibraries() (Packages, error)
+}
+
+// LibraryScanners is the set of lockfiles found on a host, one scanner per
+// lockfile.
+type LibraryScanners []LibraryScanner
@@ -0,0 +1,56 @@
+package models
+
+// Package represents a package, library, or system component discovered on
+// a scanned host.
+type Package struct {
+	Name    string
+	Version string
+
+	// Origin records which scanner found this package: "bundle" for a
+	// .app/.kext/.framework bundle, a LaunchDaemon/Agent plist, or a
+	// privileged helper tool, or the package manager that reported it
+	// ("brew", "cask", "port", "mas").
+	Origin string
+
+	// Kind distinguishes what a "bundle" Origin actually is: "app",
+	// "helper", "kext", "framework", "daemon", or "agent".
+	Kind string
+
+	// BundleIdentifier is the CFBundleIdentifier (reverse-DNS identifier,
+	// e.g. "com.google.Chrome") of a bundle or LaunchDaemon/Agent, when one
+	// is available. CPE/vendor lookups should prefer it over Name, since the
+	// display name is far less stable.
+	BundleIdentifier string
+
+	// BundleVersion is the CFBundleVersion (build number), distinct from the
+	// human-readable Version (CFBundleShortVersionString).
+	BundleVersion string
+
+	// TeamIdentifier is the codesigning team identifier of the bundle or
+	// executable, or "" if it is unsigned or ad-hoc-signed or could not be
+	// determined. Only kexts, frameworks, LaunchDaemons/Agents, and
+	// privileged helper tools are checked; .app bundles and package-manager
+	// entries always have "".
+	TeamIdentifier string
+
+	// Parent links a helper bundle (e.g. "Google Chrome Helper.app") back to
+	// the bundle it is embedded in, so a CVE that hits the parent is also
+	// reported against its children.
+	Parent *Package
+}
+
+// Packages is a map of Package, keyed by Name. An entry whose Name collides
+// with a different component may be stored under a "<Name> (<Kind>)" key.
+type Packages map[string]Package
+
+// SrcPackages is a map of source packages (a package that several binary
+// packages are built from), keyed by Name.
+type SrcPackages map[string]Package
+
+// Unknown records a coverage gap: a component whose version could not be
+// extracted and so could not be considered for vulnerability matching.
+type Unknown struct {
+	Path      string
+	Reason    string
+	RawStderr string
+}
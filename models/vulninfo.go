@@ -0,0 +1,9 @@
+package models
+
+// VulnInfo holds the detection details for a single CVE found on a host.
+type VulnInfo struct {
+	CveID string
+}
+
+// VulnInfos is a map of VulnInfo, keyed by CveID.
+type VulnInfos map[string]VulnInfo
@@ -0,0 +1,39 @@
+// Package report renders scan results for operators.
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/future-architect/vuls/models"
+)
+
+// UnknownsSection is the dedicated report section for models.Unknown
+// entries: components whose version could not be extracted during a scan,
+// so they were excluded from vulnerability matching. Reporting them
+// separately lets operators tell a genuine clean scan apart from a coverage
+// gap. The JSON tag names the section's field "unknowns" when it is
+// marshalled.
+//
+// This type and FormatUnknownsText render that section only: nothing in
+// this package adds a scan's osPackages.Unknowns to the JSON or text reports
+// that assemble the rest of a report, and there is no SARIF rendering of it.
+type UnknownsSection struct {
+	Unknowns []models.Unknown `json:"unknowns"`
+}
+
+// FormatUnknownsText renders an UnknownsSection for the text report. It
+// returns "" when there is nothing to report, so callers can omit the
+// section header entirely on a scan with full coverage.
+func FormatUnknownsText(s UnknownsSection) string {
+	if len(s.Unknowns) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Unknowns: %d component(s) could not be version-checked\n", len(s.Unknowns))
+	for _, u := range s.Unknowns {
+		fmt.Fprintf(&b, "  %s: %s\n", u.Path, u.Reason)
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/future-architect/vuls/models"
+)
+
+func Test_FormatUnknownsText(t *testing.T) {
+	tests := []struct {
+		name string
+		s    UnknownsSection
+		want string
+	}{
+		{
+			name: "empty",
+			s:    UnknownsSection{},
+			want: "",
+		},
+		{
+			name: "one unknown",
+			s: UnknownsSection{
+				Unknowns: []models.Unknown{
+					{Path: "/Applications/SomeApp.app/Contents/Info.plist", Reason: "CFBundleShortVersionString key is missing"},
+				},
+			},
+			want: "Unknowns: 1 component(s) could not be version-checked\n  /Applications/SomeApp.app/Contents/Info.plist: CFBundleShortVersionString key is missing\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatUnknownsText(tt.s); got != tt.want {
+				t.Errorf("FormatUnknownsText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"golang.org/x/xerrors"
 
@@ -17,6 +19,8 @@ import (
 // inherit OsTypeInterface
 type macos struct {
 	base
+
+	unknownsMu sync.Mutex
 }
 
 func newMacOS(c config.ServerInfo) *macos {
@@ -25,6 +29,7 @@ func newMacOS(c config.ServerInfo) *macos {
 			osPackages: osPackages{
 				Packages:  models.Packages{},
 				VulnInfos: models.VulnInfos{},
+				Unknowns:  []models.Unknown{},
 			},
 		},
 	}
@@ -140,53 +145,811 @@ func (o *macos) scanPackages() error {
 	}
 	o.Packages = installed
 
+	if err := o.scanThirdPartyPackages(); err != nil {
+		return xerrors.Errorf("Failed to scan third-party package managers. err: %w", err)
+	}
+
+	if err := o.scanLibraries(); err != nil {
+		return xerrors.Errorf("Failed to scan libraries. err: %w", err)
+	}
+
+	if err := o.scanSystemComponents(); err != nil {
+		return xerrors.Errorf("Failed to scan system components. err: %w", err)
+	}
+
 	return nil
 }
 
+// installedAppsFindCmd lists every top-level .app bundle's Info.plist
+// (excluding helper .apps embedded inside another bundle).
+const installedAppsFindCmd = `find -L /Applications /System/Applications -type f -path "*.app/Contents/Info.plist" -not -path "*.app/**/*.app/*"`
+
+// installedAppsBatchedExtractCmd lists every .app bundle's Info.plist,
+// including helpers nested inside another .app, and runs the plutil
+// extraction for each in the same exec so a scan over SSH costs one
+// round-trip instead of one per app. The output is consumed by
+// parseInstalledPackages.
+const installedAppsBatchedExtractCmd = `find -L /Applications /System/Applications -type f -path "*.app/Contents/Info.plist" -print0 | xargs -0 -n1 sh -c 'echo "Info.plist: $0"; echo "CFBundleShortVersionString: $(plutil -extract CFBundleShortVersionString raw "$0" -o - 2>&1)"; echo "CFBundleIdentifier: $(plutil -extract CFBundleIdentifier raw "$0" -o - 2>&1)"; echo "CFBundleVersion: $(plutil -extract CFBundleVersion raw "$0" -o - 2>&1)"; echo'`
+
 func (o *macos) scanInstalledPackages() (models.Packages, error) {
-	r := o.exec("find -L /Applications /System/Applications -type f -path \"*.app/Contents/Info.plist\" -not -path \"*.app/**/*.app/*\"", noSudo)
+	if (o.ServerInfo.Port == "local" || o.ServerInfo.ControlMaster) && o.concurrency() > 1 {
+		return o.scanInstalledPackagesParallel()
+	}
+	return o.scanInstalledPackagesBatched()
+}
+
+// scanInstalledPackagesBatched is the default scan path: it streams the
+// plutil extraction for every .app bundle back in a single exec, which
+// matters most when o.exec is an SSH round-trip.
+func (o *macos) scanInstalledPackagesBatched() (models.Packages, error) {
+	r := o.exec(installedAppsBatchedExtractCmd, noSudo)
+	if !r.isSuccess() {
+		return nil, xerrors.Errorf("Failed to exec: %v", r)
+	}
+
+	installed, _, err := o.parseInstalledPackages(r.Stdout)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to parse installed packages. err: %w", err)
+	}
+	return installed, nil
+}
+
+// scanInstalledPackagesParallel is used for local scans and SSH connections
+// multiplexed over a controlling master, where additional connections are
+// effectively free: rather than pay the batched path's shell-out-per-app
+// cost inside a single exec, it fans the plutil extraction out across a
+// bounded worker pool sized by o.concurrency.
+func (o *macos) scanInstalledPackagesParallel() (models.Packages, error) {
+	r := o.exec(installedAppsFindCmd, noSudo)
 	if !r.isSuccess() {
 		return nil, xerrors.Errorf("Failed to exec: %v", r)
 	}
 
+	var paths []string
+	scanner := bufio.NewScanner(strings.NewReader(r.Stdout))
+	for scanner.Scan() {
+		if t := scanner.Text(); t != "" {
+			paths = append(paths, t)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("Failed to scan by the scanner. err: %w", err)
+	}
+
 	installed := models.Packages{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.concurrency())
+	for _, path := range paths {
+		path := path
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
 
+			p := o.parseInstalledPackage(path, nil)
+			helpers, err := o.scanHelperApps(path, &p)
+
+			mu.Lock()
+			defer mu.Unlock()
+			installed[p.Name] = p
+			if err != nil {
+				o.log.Warnf("Failed to scan helper apps embedded in %s: %s", path, err)
+				return
+			}
+			for n, h := range helpers {
+				installed[n] = h
+			}
+		}()
+	}
+	wg.Wait()
+
+	return installed, nil
+}
+
+// concurrency returns the configured worker pool size for parallel scans,
+// defaulting to the number of logical CPUs when ServerInfo.ScanConcurrency
+// is unset.
+func (o *macos) concurrency() int {
+	if o.ServerInfo.ScanConcurrency > 0 {
+		return o.ServerInfo.ScanConcurrency
+	}
+	return runtime.NumCPU()
+}
+
+// scanHelperApps finds .app bundles embedded inside the .app whose
+// Info.plist is at parentInfoPlist (e.g. "Google Chrome Helper.app" inside
+// "Google Chrome.app/Contents/Frameworks/"), which installedAppsFindCmd
+// excludes. Each is recorded with Parent set to parent so that a
+// CVE affecting the parent bundle can also be reported against its helpers.
+func (o *macos) scanHelperApps(parentInfoPlist string, parent *models.Package) (models.Packages, error) {
+	parentDir := strings.TrimSuffix(parentInfoPlist, "/Contents/Info.plist")
+	r := o.exec(fmt.Sprintf("find -L \"%s\" -type f -path \"*.app/Contents/Info.plist\"", parentDir), noSudo)
+	if !r.isSuccess() {
+		return nil, xerrors.Errorf("Failed to exec: %v", r)
+	}
+
+	helpers := models.Packages{}
 	scanner := bufio.NewScanner(strings.NewReader(r.Stdout))
 	for scanner.Scan() {
 		t := scanner.Text()
-		n := filepath.Base(strings.TrimSuffix(t, ".app/Contents/Info.plist"))
-		var v string
-		if r := o.exec(fmt.Sprintf("plutil -extract \"CFBundleShortVersionString\" raw \"%s\" -o -", t), noSudo); r.isSuccess() {
-			v = strings.TrimSpace(r.Stdout)
+		if t == parentInfoPlist {
+			continue
 		}
-		installed[n] = models.Package{
+		p := o.parseInstalledPackage(t, parent)
+		helpers[p.Name] = p
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("Failed to scan by the scanner. err: %w", err)
+	}
+
+	return helpers, nil
+}
+
+// parseInstalledPackage extracts the name, version, and bundle identity of
+// the .app bundle whose Info.plist lives at infoPlistPath. parent links a
+// helper .app back to the bundle it is embedded in; it is nil for top-level
+// apps.
+func (o *macos) parseInstalledPackage(infoPlistPath string, parent *models.Package) models.Package {
+	kind := "app"
+	if parent != nil {
+		kind = "helper"
+	}
+	return models.Package{
+		Name:             filepath.Base(strings.TrimSuffix(infoPlistPath, ".app/Contents/Info.plist")),
+		Version:          o.extractVersion(infoPlistPath),
+		Origin:           "bundle",
+		Kind:             kind,
+		BundleIdentifier: o.extractPlistValue(infoPlistPath, "CFBundleIdentifier"),
+		BundleVersion:    o.extractPlistValue(infoPlistPath, "CFBundleVersion"),
+		Parent:           parent,
+	}
+}
+
+// extractPlistValue runs `plutil -extract` for a single key and returns "" if
+// the key is missing or the plist could not be read, rather than failing the
+// whole scan over one malformed or half-written bundle.
+func (o *macos) extractPlistValue(path, key string) string {
+	r := o.exec(fmt.Sprintf("plutil -extract %q raw \"%s\" -o -", key, path), noSudo)
+	if !r.isSuccess() {
+		return ""
+	}
+	return strings.TrimSpace(r.Stdout)
+}
+
+// extractVersion is extractPlistValue specialised for
+// CFBundleShortVersionString: rather than letting a missing key or
+// unparsable plist quietly turn into an empty version (and the app silently
+// dropping out of vulnerability matching), it records a models.Unknown so
+// operators can see the coverage gap in reports.
+func (o *macos) extractVersion(infoPlistPath string) string {
+	r := o.exec(fmt.Sprintf("plutil -extract %q raw \"%s\" -o -", "CFBundleShortVersionString", infoPlistPath), noSudo)
+	if !r.isSuccess() {
+		o.recordUnknown(infoPlistPath, "plutil failed to extract CFBundleShortVersionString", r.Stderr)
+		return ""
+	}
+
+	v := strings.TrimSpace(r.Stdout)
+	switch {
+	case v == "":
+		o.recordUnknown(infoPlistPath, "CFBundleShortVersionString is empty", r.Stderr)
+	case !isSemverish(v):
+		o.recordUnknown(infoPlistPath, fmt.Sprintf("CFBundleShortVersionString %q does not look like a version", v), "")
+	}
+	return v
+}
+
+// recordUnknown appends a coverage-gap entry unless the server is configured
+// to suppress them in favor of the pre-unknowns behavior. It may be called
+// concurrently from the parallel scan path, so appends are serialized.
+func (o *macos) recordUnknown(path, reason, rawStderr string) {
+	if o.ServerInfo.IgnoreUnknowns {
+		return
+	}
+	o.unknownsMu.Lock()
+	defer o.unknownsMu.Unlock()
+	o.Unknowns = append(o.Unknowns, models.Unknown{
+		Path:      path,
+		Reason:    reason,
+		RawStderr: rawStderr,
+	})
+}
+
+// isSemverish reports whether v looks like a dotted numeric version string
+// (e.g. "115.0.5790.114"). It is intentionally permissive: the goal is only
+// to flag values that are clearly not a version at all.
+func isSemverish(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, r := range v {
+		if r != '.' && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// scanThirdPartyPackages augments the .app bundle inventory with packages
+// managed by Homebrew, MacPorts, and the Mac App Store. Each source can be
+// toggled independently via ServerInfo.MacOS, since not every host has all
+// three package managers installed.
+func (o *macos) scanThirdPartyPackages() error {
+	if o.ServerInfo.MacOS.ScanHomebrew {
+		pkgs, err := o.scanHomebrewPackages()
+		if err != nil {
+			return xerrors.Errorf("Failed to scan Homebrew packages. err: %w", err)
+		}
+		o.mergePackages(pkgs)
+	}
+
+	if o.ServerInfo.MacOS.ScanHomebrewCask {
+		pkgs, err := o.scanHomebrewCaskPackages()
+		if err != nil {
+			return xerrors.Errorf("Failed to scan Homebrew cask packages. err: %w", err)
+		}
+		o.mergePackages(pkgs)
+	}
+
+	if o.ServerInfo.MacOS.ScanMacPorts {
+		pkgs, err := o.scanMacPortsPackages()
+		if err != nil {
+			return xerrors.Errorf("Failed to scan MacPorts packages. err: %w", err)
+		}
+		o.mergePackages(pkgs)
+	}
+
+	if o.ServerInfo.MacOS.ScanMacAppStore {
+		pkgs, err := o.scanMacAppStorePackages()
+		if err != nil {
+			return xerrors.Errorf("Failed to scan Mac App Store packages. err: %w", err)
+		}
+		o.mergePackages(pkgs)
+	}
+
+	return nil
+}
+
+// mergePackages folds pkgs into o.Packages, keeping whichever entry was
+// discovered first. The .app bundle scan always runs, so casks and apps
+// that are also visible under /Applications are credited to that scan
+// rather than being duplicated under a package manager origin. Matching is
+// done by CFBundleIdentifier when both sides have one, since display names
+// (and thus map keys) can collide or drift between a bundle and the name a
+// package manager gives it. When a Name collides and at least one side has
+// no BundleIdentifier, falling back to Name alone is only safe when both
+// sides also agree on Kind: a LaunchDaemon (named after its Label) and a
+// privileged helper tool (named after its file, with no BundleIdentifier)
+// can legitimately share a Name (e.g. "com.docker.vmnetd"), and treating
+// that as the same entry would silently drop one of two genuinely different
+// components. Entries whose Names collide but whose BundleIdentifiers differ
+// are likewise different components. In both cases the incoming entry is
+// kept under a disambiguated "<Name> (<Kind>)" key instead.
+func (o *macos) mergePackages(pkgs models.Packages) {
+	byBundleID := map[string]string{}
+	for n, p := range o.Packages {
+		if p.BundleIdentifier != "" {
+			byBundleID[p.BundleIdentifier] = n
+		}
+	}
+
+	for n, p := range pkgs {
+		if p.BundleIdentifier != "" {
+			if _, ok := byBundleID[p.BundleIdentifier]; ok {
+				continue
+			}
+		}
+		if existing, ok := o.Packages[n]; ok {
+			if (p.BundleIdentifier == "" || existing.BundleIdentifier == "") && existing.Kind == p.Kind {
+				continue
+			}
+			o.log.Debugf("Name collision between existing %s %q and incoming %s %q that are different components, keeping both", existing.Kind, n, p.Kind, n)
+			n = fmt.Sprintf("%s (%s)", n, p.Kind)
+		}
+		o.Packages[n] = p
+	}
+}
+
+// scanHomebrewPackages lists formulae installed via Homebrew. Hosts without
+// Homebrew simply report no packages rather than failing the whole scan.
+func (o *macos) scanHomebrewPackages() (models.Packages, error) {
+	r := o.exec("brew list --versions", noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec `brew list --versions`, Homebrew is probably not installed: %v", r)
+		return models.Packages{}, nil
+	}
+	return parseBrewListVersions(r.Stdout, "brew"), nil
+}
+
+// scanHomebrewCaskPackages lists casks installed via Homebrew. Casks often
+// install GUI apps outside of /Applications, so they are not covered by
+// scanInstalledPackages. Most casks install a .app bundle, so each cask's
+// BundleIdentifier/BundleVersion is looked up the same way
+// scanMacAppStorePackages does, letting mergePackages dedupe it against the
+// .app scan instead of double-counting it under a different map key.
+func (o *macos) scanHomebrewCaskPackages() (models.Packages, error) {
+	r := o.exec("brew list --cask --versions", noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec `brew list --cask --versions`, no Homebrew casks found: %v", r)
+		return models.Packages{}, nil
+	}
+
+	pkgs := parseBrewListVersions(r.Stdout, "cask")
+	for n, p := range pkgs {
+		appPath := o.caskAppPath(n)
+		if appPath == "" {
+			continue
+		}
+		infoPlist := fmt.Sprintf("%s/Contents/Info.plist", appPath)
+		p.BundleIdentifier = o.extractPlistValue(infoPlist, "CFBundleIdentifier")
+		p.BundleVersion = o.extractPlistValue(infoPlist, "CFBundleVersion")
+		pkgs[n] = p
+	}
+	return pkgs, nil
+}
+
+// caskAppPath resolves the .app bundle a Homebrew cask installed, or "" if
+// the cask didn't install one (e.g. a command-line tool or font cask).
+func (o *macos) caskAppPath(name string) string {
+	r := o.exec(fmt.Sprintf("brew list --cask -1 %q", name), noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec `brew list --cask -1 %s`: %v", name, r)
+		return ""
+	}
+	return parseCaskAppPath(r.Stdout)
+}
+
+// parseCaskAppPath parses the output of `brew list --cask -1 <name>`, one
+// installed file path per line, and returns the first one that is a .app
+// bundle.
+func parseCaskAppPath(stdout string) string {
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		if t := strings.TrimSpace(scanner.Text()); strings.HasSuffix(t, ".app") {
+			return t
+		}
+	}
+	return ""
+}
+
+// parseBrewListVersions parses the output of `brew list --versions` (and its
+// `--cask` counterpart), which is one package per line: "<name> <version...>".
+// Casks and some formulae list multiple installed versions; the last one on
+// the line is the newest and is what brew will actually load.
+func parseBrewListVersions(stdout, origin string) models.Packages {
+	pkgs := models.Packages{}
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		n := fields[0]
+		pkgs[n] = models.Package{
 			Name:    n,
-			Version: v,
+			Version: fields[len(fields)-1],
+			Origin:  origin,
+		}
+	}
+	return pkgs
+}
+
+// scanMacPortsPackages lists ports installed via MacPorts.
+func (o *macos) scanMacPortsPackages() (models.Packages, error) {
+	r := o.exec("port installed", noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec `port installed`, MacPorts is probably not installed: %v", r)
+		return models.Packages{}, nil
+	}
+	return parsePortInstalled(r.Stdout), nil
+}
+
+// parsePortInstalled parses the output of `port installed`, which looks
+// like:
+//
+//	The following ports are currently installed:
+//	  zlib @1.2.13_0 (active)
+//	  openssl @1.1.1t_0 (active)
+func parsePortInstalled(stdout string) models.Packages {
+	pkgs := models.Packages{}
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "@") {
+			continue
+		}
+		n := fields[0]
+		pkgs[n] = models.Package{
+			Name:    n,
+			Version: strings.TrimPrefix(fields[1], "@"),
+			Origin:  "port",
+		}
+	}
+	return pkgs
+}
+
+// scanMacAppStorePackages lists apps that carry a Mac App Store purchase
+// receipt, found via the Spotlight kMDItemAppStoreHasReceipt attribute
+// rather than walking /Library/Application Support/App Store/ receipts
+// directly, since the receipt format is undocumented and has changed
+// across macOS releases. A Mac App Store app is still just a .app bundle, so
+// its Info.plist is extracted the same way as the other bundle sources.
+func (o *macos) scanMacAppStorePackages() (models.Packages, error) {
+	r := o.exec(`mdfind "kMDItemAppStoreHasReceipt=1"`, noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec `mdfind kMDItemAppStoreHasReceipt`, no Mac App Store apps found: %v", r)
+		return models.Packages{}, nil
+	}
+
+	pkgs := models.Packages{}
+	scanner := bufio.NewScanner(strings.NewReader(r.Stdout))
+	for scanner.Scan() {
+		p := scanner.Text()
+		if p == "" {
+			continue
+		}
+		n := strings.TrimSuffix(filepath.Base(p), ".app")
+		infoPlist := fmt.Sprintf("%s/Contents/Info.plist", p)
+		pkgs[n] = models.Package{
+			Name:             n,
+			Version:          o.extractVersion(infoPlist),
+			Origin:           "mas",
+			BundleIdentifier: o.extractPlistValue(infoPlist, "CFBundleIdentifier"),
+			BundleVersion:    o.extractPlistValue(infoPlist, "CFBundleVersion"),
 		}
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, xerrors.Errorf("Failed to scan by the scanner. err: %w", err)
 	}
 
-	return installed, nil
+	return pkgs, nil
+}
+
+// libraryLockFiles are the lockfile names scanLibraries looks for under each
+// configured root, mirroring what the Linux scanners already detect.
+var libraryLockFiles = []string{
+	"package-lock.json",
+	"yarn.lock",
+	"Pipfile.lock",
+	"poetry.lock",
+	"Gemfile.lock",
+	"Cargo.lock",
+}
+
+// scanLibraries walks ServerInfo.MacOS.LibraryScanRoots for language-
+// ecosystem lockfiles and feeds them into the same models.LibraryScanners
+// pipeline the Linux scanners use, so report treats macOS findings
+// identically.
+func (o *macos) scanLibraries() error {
+	roots := o.ServerInfo.MacOS.LibraryScanRoots
+	if len(roots) == 0 {
+		roots = o.defaultLibraryScanRoots()
+	}
+
+	paths, err := o.findLockFiles(roots)
+	if err != nil {
+		return xerrors.Errorf("Failed to find lockfiles. err: %w", err)
+	}
+
+	for _, path := range paths {
+		library, err := GetLibraryScanner(path, nil)
+		if err != nil {
+			o.log.Warnf("Failed to scan library file %s: %s", path, err)
+			continue
+		}
+		o.LibraryScanners = append(o.LibraryScanners, library)
+	}
+
+	return nil
+}
+
+// defaultLibraryScanRoots mirrors the common install locations for Node.js,
+// Python, Ruby, and Rust tooling on macOS. $HOME is expanded by the remote
+// shell o.exec runs the find command through.
+func (o *macos) defaultLibraryScanRoots() []string {
+	return []string{
+		"$HOME",
+		"/usr/local/lib/node_modules",
+		"/usr/local/Cellar",
+		"/opt/homebrew/Cellar",
+		"$HOME/.pyenv",
+		"$HOME/.rbenv",
+	}
+}
+
+// findLockFiles searches roots for any of libraryLockFiles.
+func (o *macos) findLockFiles(roots []string) ([]string, error) {
+	nameArgs := make([]string, 0, len(libraryLockFiles)*2)
+	for i, n := range libraryLockFiles {
+		if i > 0 {
+			nameArgs = append(nameArgs, "-o")
+		}
+		nameArgs = append(nameArgs, "-name", n)
+	}
+
+	quotedRoots := make([]string, len(roots))
+	for i, root := range roots {
+		quotedRoots[i] = fmt.Sprintf("%q", root)
+	}
+
+	cmd := fmt.Sprintf(`find -L %s -type f \( %s \) 2>/dev/null`, strings.Join(quotedRoots, " "), strings.Join(nameArgs, " "))
+	r := o.exec(cmd, noSudo)
+	if !r.isSuccess() {
+		return nil, xerrors.Errorf("Failed to exec: %v", r)
+	}
+
+	return parseFindPaths(r.Stdout)
+}
+
+// parseFindPaths parses the newline-delimited output of a `find` invocation
+// into a list of non-empty paths. It is shared by findLockFiles,
+// scanBundleComponents, scanLaunchPlists, and scanPrivilegedHelperTools.
+func parseFindPaths(stdout string) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		if t := scanner.Text(); t != "" {
+			paths = append(paths, t)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("Failed to scan by the scanner. err: %w", err)
+	}
+
+	return paths, nil
+}
+
+// scanSystemComponents enumerates the macOS components that CVEs are most
+// often filed against but that scanInstalledPackages never sees: kernel
+// extensions, privileged helper tools, LaunchDaemons/Agents, and shared
+// frameworks. Each is tagged with a Kind so report can distinguish it from
+// an ordinary app, along with its codesigning team identifier so unsigned
+// or ad-hoc-signed components can be flagged.
+func (o *macos) scanSystemComponents() error {
+	kexts, err := o.scanBundleComponents("kext", []string{"/Library/Extensions", "/System/Library/Extensions"}, "*.kext/Contents/Info.plist")
+	if err != nil {
+		return xerrors.Errorf("Failed to scan kernel extensions. err: %w", err)
+	}
+	o.mergePackages(kexts)
+
+	frameworks, err := o.scanBundleComponents("framework", []string{"/System/Library/Frameworks"}, "*.framework/Resources/Info.plist")
+	if err != nil {
+		return xerrors.Errorf("Failed to scan frameworks. err: %w", err)
+	}
+	o.mergePackages(frameworks)
+
+	daemons, err := o.scanLaunchPlists("daemon", []string{"/Library/LaunchDaemons"})
+	if err != nil {
+		return xerrors.Errorf("Failed to scan LaunchDaemons. err: %w", err)
+	}
+	o.mergePackages(daemons)
+
+	agents, err := o.scanLaunchPlists("agent", []string{"/Library/LaunchAgents", "$HOME/Library/LaunchAgents"})
+	if err != nil {
+		return xerrors.Errorf("Failed to scan LaunchAgents. err: %w", err)
+	}
+	o.mergePackages(agents)
+
+	helpers, err := o.scanPrivilegedHelperTools()
+	if err != nil {
+		return xerrors.Errorf("Failed to scan privileged helper tools. err: %w", err)
+	}
+	o.mergePackages(helpers)
+
+	return nil
+}
+
+// scanBundleComponents finds bundles matching infoPlistGlob under roots
+// (kexts and frameworks, which carry an Info.plist the same way .app
+// bundles do) and records them with the given Kind.
+func (o *macos) scanBundleComponents(kind string, roots []string, infoPlistGlob string) (models.Packages, error) {
+	quotedRoots := make([]string, len(roots))
+	for i, root := range roots {
+		quotedRoots[i] = fmt.Sprintf("%q", root)
+	}
+
+	r := o.exec(fmt.Sprintf(`find -L %s -type f -path %q 2>/dev/null`, strings.Join(quotedRoots, " "), infoPlistGlob), noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec find for %s components: %v", kind, r)
+		return models.Packages{}, nil
+	}
+
+	paths, err := parseFindPaths(r.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := models.Packages{}
+	for _, path := range paths {
+		n, bundleDir := bundleComponentName(path)
+		pkgs[n] = models.Package{
+			Name:             n,
+			Version:          o.extractPlistValue(path, "CFBundleShortVersionString"),
+			Origin:           "bundle",
+			Kind:             kind,
+			BundleIdentifier: o.extractPlistValue(path, "CFBundleIdentifier"),
+			BundleVersion:    o.extractPlistValue(path, "CFBundleVersion"),
+			TeamIdentifier:   o.codesignTeamIdentifier(bundleDir),
+		}
+	}
+
+	return pkgs, nil
+}
+
+// bundleComponentName derives the Package Name and on-disk bundle directory
+// for a kext/framework Info.plist path, stripping whichever of the two
+// Info.plist locations (a kext's "Contents/Info.plist" or a framework's
+// "Resources/Info.plist") infoPlistPath ends with.
+func bundleComponentName(infoPlistPath string) (name, bundleDir string) {
+	bundleDir = strings.TrimSuffix(strings.TrimSuffix(infoPlistPath, "/Contents/Info.plist"), "/Resources/Info.plist")
+	return filepath.Base(bundleDir), bundleDir
+}
+
+// scanLaunchPlists finds LaunchDaemon/LaunchAgent .plist files under roots.
+// These are flat plists rather than bundles, identified by their Label key
+// instead of a CFBundle* key.
+func (o *macos) scanLaunchPlists(kind string, roots []string) (models.Packages, error) {
+	quotedRoots := make([]string, len(roots))
+	for i, root := range roots {
+		quotedRoots[i] = fmt.Sprintf("%q", root)
+	}
+
+	r := o.exec(fmt.Sprintf(`find -L %s -type f -name "*.plist" 2>/dev/null`, strings.Join(quotedRoots, " ")), noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec find for %s plists: %v", kind, r)
+		return models.Packages{}, nil
+	}
+
+	paths, err := parseFindPaths(r.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := models.Packages{}
+	for _, path := range paths {
+		label := o.extractPlistValue(path, "Label")
+		n := launchPlistName(path, label)
+		pkgs[n] = models.Package{
+			Name:             n,
+			Origin:           "bundle",
+			Kind:             kind,
+			BundleIdentifier: label,
+			TeamIdentifier:   o.codesignTeamIdentifier(o.extractPlistValue(path, "Program")),
+		}
+	}
+
+	return pkgs, nil
+}
+
+// launchPlistName falls back to the plist's filename (minus extension) when
+// a LaunchDaemon/Agent plist has no Label key.
+func launchPlistName(path, label string) string {
+	if label != "" {
+		return label
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".plist")
+}
+
+// scanPrivilegedHelperTools finds the privileged helper executables
+// installed under /Library/PrivilegedHelperTools. Unlike the other system
+// components these are plain binaries with no plist, so only their
+// codesigning identity is recorded.
+func (o *macos) scanPrivilegedHelperTools() (models.Packages, error) {
+	r := o.exec(`find -L /Library/PrivilegedHelperTools -type f 2>/dev/null`, noSudo)
+	if !r.isSuccess() {
+		o.log.Debugf("Failed to exec find for privileged helper tools: %v", r)
+		return models.Packages{}, nil
+	}
+
+	paths, err := parseFindPaths(r.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := models.Packages{}
+	for _, path := range paths {
+		n := filepath.Base(path)
+		pkgs[n] = models.Package{
+			Name:           n,
+			Origin:         "bundle",
+			Kind:           "helper",
+			TeamIdentifier: o.codesignTeamIdentifier(path),
+		}
+	}
+
+	return pkgs, nil
 }
 
+// codesignTeamIdentifier runs `codesign -dv --verbose=4` against path and
+// returns its TeamIdentifier, or "" for an unsigned or ad-hoc-signed binary
+// (codesign reports "not set" in that case) or when path is unknown.
+func (o *macos) codesignTeamIdentifier(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	r := o.exec(fmt.Sprintf(`codesign -dv --verbose=4 "%s" 2>&1`, path), noSudo)
+	if !r.isSuccess() {
+		return ""
+	}
+	return parseCodesignTeamIdentifier(r.Stdout)
+}
+
+// parseCodesignTeamIdentifier extracts the TeamIdentifier line from
+// `codesign -dv --verbose=4` output, returning "" when the binary is
+// unsigned or ad-hoc-signed (codesign reports "not set" in that case).
+func parseCodesignTeamIdentifier(stdout string) string {
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		t := scanner.Text()
+		if !strings.HasPrefix(t, "TeamIdentifier=") {
+			continue
+		}
+		if v := strings.TrimPrefix(t, "TeamIdentifier="); v != "not set" {
+			return v
+		}
+		return ""
+	}
+	return ""
+}
+
+// parseInstalledPackages parses the multi-record plutil output produced by
+// running the extraction over every discovered Info.plist in one shot (see
+// scanInstalledPackages). Records are emitted in find's directory-tree
+// order, so a helper .app embedded inside another bundle (more than one
+// ".app/Contents/" segment in its path) always follows the top-level record
+// for the bundle it belongs to; that top-level record becomes its Parent.
 func (o *macos) parseInstalledPackages(stdout string) (models.Packages, models.SrcPackages, error) {
 	pkgs := models.Packages{}
-	var p, v string
+	var path, version, rawVersion, bundleID, bundleVersion string
+	var parent *models.Package
+
+	flush := func() {
+		if path == "" {
+			return
+		}
+		n := filepath.Base(strings.TrimSuffix(path, ".app/Contents/Info.plist"))
+		switch {
+		case version == "":
+			o.recordUnknown(path, "CFBundleShortVersionString key is missing or could not be read", rawVersion)
+		case !isSemverish(version):
+			o.recordUnknown(path, fmt.Sprintf("CFBundleShortVersionString %q does not look like a version", version), "")
+		}
+		nested := strings.Count(path, ".app/Contents/") > 1
+		kind := "app"
+		if nested {
+			kind = "helper"
+		}
+		p := models.Package{
+			Name:             n,
+			Version:          version,
+			Origin:           "bundle",
+			Kind:             kind,
+			BundleIdentifier: bundleID,
+			BundleVersion:    bundleVersion,
+		}
+		if nested {
+			p.Parent = parent
+		} else {
+			parent = &models.Package{
+				Name:             n,
+				Version:          version,
+				Origin:           "bundle",
+				Kind:             kind,
+				BundleIdentifier: bundleID,
+				BundleVersion:    bundleVersion,
+			}
+		}
+		pkgs[n] = p
+		path, version, rawVersion, bundleID, bundleVersion = "", "", "", "", ""
+	}
 
 	scanner := bufio.NewScanner(strings.NewReader(stdout))
 	for scanner.Scan() {
 		t := scanner.Text()
 		if t == "" {
-			if p != "" {
-				n := filepath.Base(strings.TrimSuffix(p, ".app/Contents/Info.plist"))
-				pkgs[n] = models.Package{
-					Name:    n,
-					Version: v,
-				}
-			}
-			p, v = "", ""
+			flush()
 			continue
 		}
 
@@ -197,24 +960,28 @@ func (o *macos) parseInstalledPackages(stdout string) (models.Packages, models.S
 
 		switch lhs {
 		case "Info.plist":
-			p = strings.TrimSpace(rhs)
+			path = strings.TrimSpace(rhs)
 		case "CFBundleShortVersionString":
-			v = strings.TrimSpace(rhs)
-			if strings.HasSuffix(v, "error: No value at that key path or invalid key path: CFBundleShortVersionString") {
-				v = ""
+			version = strings.TrimSpace(rhs)
+			rawVersion = version
+			if strings.HasSuffix(version, "error: No value at that key path or invalid key path: CFBundleShortVersionString") {
+				version = ""
+			}
+		case "CFBundleIdentifier":
+			bundleID = strings.TrimSpace(rhs)
+			if strings.HasSuffix(bundleID, "error: No value at that key path or invalid key path: CFBundleIdentifier") {
+				bundleID = ""
+			}
+		case "CFBundleVersion":
+			bundleVersion = strings.TrimSpace(rhs)
+			if strings.HasSuffix(bundleVersion, "error: No value at that key path or invalid key path: CFBundleVersion") {
+				bundleVersion = ""
 			}
 		default:
-			return nil, nil, xerrors.Errorf("unexpected installed packages line tag. expected: [\"Info.plist\", \"CFBundleShortVersionString\"], actual: \"%s\"", lhs)
-		}
-
-	}
-	if p != "" {
-		n := filepath.Base(strings.TrimSuffix(p, ".app/Contents/Info.plist"))
-		pkgs[n] = models.Package{
-			Name:    n,
-			Version: v,
+			return nil, nil, xerrors.Errorf("unexpected installed packages line tag. expected: [\"Info.plist\", \"CFBundleShortVersionString\", \"CFBundleIdentifier\", \"CFBundleVersion\"], actual: \"%s\"", lhs)
 		}
 	}
+	flush()
 	if err := scanner.Err(); err != nil {
 		return nil, nil, xerrors.Errorf("Failed to scan by the scanner. err: %w", err)
 	}
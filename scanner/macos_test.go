@@ -1,10 +1,16 @@
 package scanner
 
 import (
+	"fmt"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/future-architect/vuls/constant"
+	"github.com/future-architect/vuls/logging"
 	"github.com/future-architect/vuls/models"
 )
 
@@ -104,22 +110,32 @@ CFBundleShortVersionString: /System/Applications/Contacts.app/Contents/Info.plis
 				"Visual Studio Code": {
 					Name:    "Visual Studio Code",
 					Version: "1.80.1",
+					Origin:  "bundle",
+					Kind:    "app",
 				},
 				"Safari": {
 					Name:    "Safari",
 					Version: "16.5.1",
+					Origin:  "bundle",
+					Kind:    "app",
 				},
 				"Firefox": {
 					Name:    "Firefox",
 					Version: "115.0.2",
+					Origin:  "bundle",
+					Kind:    "app",
 				},
 				"Slack": {
 					Name:    "Slack",
 					Version: "4.33.73",
+					Origin:  "bundle",
+					Kind:    "app",
 				},
 				"Contacts": {
 					Name:    "Contacts",
 					Version: "",
+					Origin:  "bundle",
+					Kind:    "app",
 				},
 			},
 		},
@@ -138,3 +154,504 @@ CFBundleShortVersionString: /System/Applications/Contacts.app/Contents/Info.plis
 		})
 	}
 }
+
+func Test_macos_parseInstalledPackages_unknowns(t *testing.T) {
+	stdout := `Info.plist: /System/Applications/Contacts.app/Contents/Info.plist
+CFBundleShortVersionString: /System/Applications/Contacts.app/Contents/Info.plist: Could not extract value, error: No value at that key path or invalid key path: CFBundleShortVersionString
+
+Info.plist: /Applications/SomeBeta.app/Contents/Info.plist
+CFBundleShortVersionString: 1.0-beta
+`
+
+	o := &macos{}
+	if _, _, err := o.parseInstalledPackages(stdout); err != nil {
+		t.Fatalf("macos.parseInstalledPackages() error = %v", err)
+	}
+	if len(o.Unknowns) != 2 {
+		t.Fatalf("macos.parseInstalledPackages() Unknowns count = %d, want 2: %v", len(o.Unknowns), o.Unknowns)
+	}
+	if o.Unknowns[0].Path != "/System/Applications/Contacts.app/Contents/Info.plist" {
+		t.Errorf("macos.parseInstalledPackages() Unknowns[0].Path = %s", o.Unknowns[0].Path)
+	}
+	if o.Unknowns[1].Path != "/Applications/SomeBeta.app/Contents/Info.plist" {
+		t.Errorf("macos.parseInstalledPackages() Unknowns[1].Path = %s", o.Unknowns[1].Path)
+	}
+}
+
+func Test_macos_parseInstalledPackages_ignoreUnknowns(t *testing.T) {
+	stdout := `Info.plist: /System/Applications/Contacts.app/Contents/Info.plist
+CFBundleShortVersionString: /System/Applications/Contacts.app/Contents/Info.plist: Could not extract value, error: No value at that key path or invalid key path: CFBundleShortVersionString
+`
+
+	o := &macos{}
+	o.ServerInfo.IgnoreUnknowns = true
+	if _, _, err := o.parseInstalledPackages(stdout); err != nil {
+		t.Fatalf("macos.parseInstalledPackages() error = %v", err)
+	}
+	if len(o.Unknowns) != 0 {
+		t.Errorf("macos.parseInstalledPackages() Unknowns count = %d, want 0: %v", len(o.Unknowns), o.Unknowns)
+	}
+}
+
+func Test_isSemverish(t *testing.T) {
+	tests := []struct {
+		v    string
+		want bool
+	}{
+		{"115.0.5790.114", true},
+		{"1.80.1", true},
+		{"", false},
+		{"1.0-beta", false},
+		{"not-a-version", false},
+	}
+	for _, tt := range tests {
+		if got := isSemverish(tt.v); got != tt.want {
+			t.Errorf("isSemverish(%q) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func Test_macos_parseInstalledPackages_helperApps(t *testing.T) {
+	stdout := `Info.plist: /Applications/Google Chrome.app/Contents/Info.plist
+CFBundleShortVersionString: 115.0.5790.114
+CFBundleIdentifier: com.google.Chrome
+CFBundleVersion: 5790.114
+
+Info.plist: /Applications/Google Chrome.app/Contents/Frameworks/Google Chrome Framework.framework/Versions/115.0.5790.114/Helpers/Google Chrome Helper.app/Contents/Info.plist
+CFBundleShortVersionString: 115.0.5790.114
+CFBundleIdentifier: com.google.Chrome.helper
+CFBundleVersion: 5790.114
+`
+	want := models.Packages{
+		"Google Chrome": {
+			Name:             "Google Chrome",
+			Version:          "115.0.5790.114",
+			Origin:           "bundle",
+			Kind:             "app",
+			BundleIdentifier: "com.google.Chrome",
+			BundleVersion:    "5790.114",
+		},
+		"Google Chrome Helper": {
+			Name:             "Google Chrome Helper",
+			Version:          "115.0.5790.114",
+			Origin:           "bundle",
+			Kind:             "helper",
+			BundleIdentifier: "com.google.Chrome.helper",
+			BundleVersion:    "5790.114",
+			Parent: &models.Package{
+				Name:             "Google Chrome",
+				Version:          "115.0.5790.114",
+				Origin:           "bundle",
+				Kind:             "app",
+				BundleIdentifier: "com.google.Chrome",
+				BundleVersion:    "5790.114",
+			},
+		},
+	}
+
+	o := &macos{}
+	got, _, err := o.parseInstalledPackages(stdout)
+	if err != nil {
+		t.Fatalf("macos.parseInstalledPackages() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("macos.parseInstalledPackages() got = %v, want %v", got, want)
+	}
+}
+
+func Test_parseBrewListVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		origin string
+		want   models.Packages
+	}{
+		{
+			name: "formulae",
+			stdout: `openssl@3 3.1.2
+sqlite 3.42.0
+wget 1.21.4`,
+			origin: "brew",
+			want: models.Packages{
+				"openssl@3": {Name: "openssl@3", Version: "3.1.2", Origin: "brew"},
+				"sqlite":    {Name: "sqlite", Version: "3.42.0", Origin: "brew"},
+				"wget":      {Name: "wget", Version: "1.21.4", Origin: "brew"},
+			},
+		},
+		{
+			name: "cask with multiple installed versions",
+			stdout: `google-chrome 114.0.5735.198 115.0.5790.114
+docker 4.21.1,93002`,
+			origin: "cask",
+			want: models.Packages{
+				"google-chrome": {Name: "google-chrome", Version: "115.0.5790.114", Origin: "cask"},
+				"docker":        {Name: "docker", Version: "4.21.1,93002", Origin: "cask"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBrewListVersions(tt.stdout, tt.origin)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBrewListVersions() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parsePortInstalled(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   models.Packages
+	}{
+		{
+			name: "happy",
+			stdout: `The following ports are currently installed:
+  zlib @1.2.13_0 (active)
+  openssl @1.1.1t_0 (active)
+  expat @2.5.0_1`,
+			want: models.Packages{
+				"zlib":    {Name: "zlib", Version: "1.2.13_0", Origin: "port"},
+				"openssl": {Name: "openssl", Version: "1.1.1t_0", Origin: "port"},
+				"expat":   {Name: "expat", Version: "2.5.0_1", Origin: "port"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePortInstalled(tt.stdout)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePortInstalled() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseCaskAppPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   string
+	}{
+		{
+			name:   "app cask",
+			stdout: "/opt/homebrew/Caskroom/google-chrome/117.0.5938.149/Google Chrome.app\n",
+			want:   "/opt/homebrew/Caskroom/google-chrome/117.0.5938.149/Google Chrome.app",
+		},
+		{
+			name: "app cask with sibling files",
+			stdout: `/opt/homebrew/Caskroom/docker/4.21.1/Docker.app
+/opt/homebrew/Caskroom/docker/4.21.1/.metadata/4.21.1/Casks/docker.rb`,
+			want: "/opt/homebrew/Caskroom/docker/4.21.1/Docker.app",
+		},
+		{
+			name:   "no app (e.g. a CLI-only cask)",
+			stdout: "/opt/homebrew/Caskroom/some-cli/1.0/some-cli\n",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCaskAppPath(tt.stdout); got != tt.want {
+				t.Errorf("parseCaskAppPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_macos_mergePackages_bundleIdentifierMatch(t *testing.T) {
+	o := &macos{}
+	o.log = logging.NewNormalLogger()
+	o.Packages = models.Packages{
+		"Google Chrome": {
+			Name:             "Google Chrome",
+			Version:          "117.0.5938.149",
+			Origin:           "bundle",
+			Kind:             "app",
+			BundleIdentifier: "com.google.Chrome",
+		},
+	}
+
+	o.mergePackages(models.Packages{
+		"google-chrome": {
+			Name:             "google-chrome",
+			Version:          "117.0.5938.149",
+			Origin:           "cask",
+			BundleIdentifier: "com.google.Chrome",
+		},
+	})
+
+	if len(o.Packages) != 1 {
+		t.Fatalf("macos.mergePackages() Packages count = %d, want 1 (cask should dedupe against the bundle entry by BundleIdentifier): %v", len(o.Packages), o.Packages)
+	}
+	if _, ok := o.Packages["google-chrome"]; ok {
+		t.Errorf("macos.mergePackages() kept the cask entry under its own key instead of deduping by BundleIdentifier: %v", o.Packages)
+	}
+	bundle, ok := o.Packages["Google Chrome"]
+	if !ok || bundle.Origin != "bundle" {
+		t.Errorf("macos.mergePackages() did not keep the original bundle entry: %v", o.Packages)
+	}
+}
+
+func Test_macos_mergePackages_nameCollisionAcrossKinds(t *testing.T) {
+	o := &macos{}
+	o.log = logging.NewNormalLogger()
+	o.Packages = models.Packages{
+		"com.docker.vmnetd": {
+			Name:             "com.docker.vmnetd",
+			Origin:           "bundle",
+			Kind:             "daemon",
+			BundleIdentifier: "com.docker.vmnetd",
+		},
+	}
+
+	o.mergePackages(models.Packages{
+		"com.docker.vmnetd": {
+			Name:   "com.docker.vmnetd",
+			Origin: "bundle",
+			Kind:   "helper",
+		},
+	})
+
+	if len(o.Packages) != 2 {
+		t.Fatalf("macos.mergePackages() Packages count = %d, want 2: %v", len(o.Packages), o.Packages)
+	}
+	if _, ok := o.Packages["com.docker.vmnetd"]; !ok {
+		t.Errorf("macos.mergePackages() dropped the existing daemon entry")
+	}
+	helper, ok := o.Packages["com.docker.vmnetd (helper)"]
+	if !ok {
+		t.Fatalf("macos.mergePackages() did not keep the colliding helper entry under a disambiguated key: %v", o.Packages)
+	}
+	if helper.Kind != "helper" {
+		t.Errorf("macos.mergePackages() helper.Kind = %q, want \"helper\"", helper.Kind)
+	}
+}
+
+func Test_parseFindPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdout  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "happy",
+			stdout: `/Users/me/project/package-lock.json
+/Users/me/project/Cargo.lock
+`,
+			want: []string{"/Users/me/project/package-lock.json", "/Users/me/project/Cargo.lock"},
+		},
+		{
+			name:   "blank lines are skipped",
+			stdout: "\n/Users/me/project/Gemfile.lock\n\n",
+			want:   []string{"/Users/me/project/Gemfile.lock"},
+		},
+		{
+			name:   "no matches",
+			stdout: "",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFindPaths(tt.stdout)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFindPaths() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFindPaths() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bundleComponentName(t *testing.T) {
+	tests := []struct {
+		name          string
+		infoPlistPath string
+		wantName      string
+		wantBundleDir string
+	}{
+		{
+			name:          "kext",
+			infoPlistPath: "/System/Library/Extensions/IOUSBHostFamily.kext/Contents/Info.plist",
+			wantName:      "IOUSBHostFamily.kext",
+			wantBundleDir: "/System/Library/Extensions/IOUSBHostFamily.kext",
+		},
+		{
+			name:          "framework",
+			infoPlistPath: "/System/Library/Frameworks/CoreFoundation.framework/Resources/Info.plist",
+			wantName:      "CoreFoundation.framework",
+			wantBundleDir: "/System/Library/Frameworks/CoreFoundation.framework",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, gotBundleDir := bundleComponentName(tt.infoPlistPath)
+			if gotName != tt.wantName || gotBundleDir != tt.wantBundleDir {
+				t.Errorf("bundleComponentName() = (%q, %q), want (%q, %q)", gotName, gotBundleDir, tt.wantName, tt.wantBundleDir)
+			}
+		})
+	}
+}
+
+func Test_launchPlistName(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		label string
+		want  string
+	}{
+		{
+			name:  "labeled",
+			path:  "/Library/LaunchDaemons/com.docker.vmnetd.plist",
+			label: "com.docker.vmnetd",
+			want:  "com.docker.vmnetd",
+		},
+		{
+			name: "no label falls back to filename",
+			path: "/Library/LaunchAgents/com.example.agent.plist",
+			want: "com.example.agent",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := launchPlistName(tt.path, tt.label); got != tt.want {
+				t.Errorf("launchPlistName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseCodesignTeamIdentifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   string
+	}{
+		{
+			name: "signed",
+			stdout: `Executable=/Library/PrivilegedHelperTools/com.docker.vmnetd
+Identifier=com.docker.vmnetd
+Format=Mach-O thin (x86_64)
+CodeDirectory v=20500 size=686 flags=0x10000(runtime) hashes=13+7 location=embedded
+Signature size=4523
+TeamIdentifier=9BNSXJN65R
+Sealed Resources=none
+Internal requirements count=1 size=180`,
+			want: "9BNSXJN65R",
+		},
+		{
+			name: "ad-hoc signed",
+			stdout: `Executable=/Library/LaunchAgents/com.example.agent
+Identifier=com.example.agent
+Format=Mach-O thin (x86_64)
+CodeDirectory v=20400 size=300 flags=0x2(adhoc) hashes=5+3 location=embedded
+Signature=adhoc
+TeamIdentifier=not set`,
+			want: "",
+		},
+		{
+			name:   "unsigned",
+			stdout: `code object is not signed at all`,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCodesignTeamIdentifier(tt.stdout); got != tt.want {
+				t.Errorf("parseCodesignTeamIdentifier() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkFixtureRecords synthesizes n plutil-extract records in the
+// multi-record format produced by installedAppsBatchedExtractCmd, to drive
+// the serial/batched/parallel comparison below without requiring a real
+// macOS host or SSH connection.
+func benchmarkFixtureRecords(n int) []string {
+	records := make([]string, n)
+	for i := range records {
+		records[i] = fmt.Sprintf(
+			"Info.plist: /Applications/App%[1]d.app/Contents/Info.plist\nCFBundleShortVersionString: 1.0.%[1]d\nCFBundleIdentifier: com.example.app%[1]d\nCFBundleVersion: %[1]d\n",
+			i,
+		)
+	}
+	return records
+}
+
+// simulatedExecRoundTrip stands in for the cost of a single o.exec call
+// (e.g. over SSH) in the benchmarks below. There is no live connection (or
+// base.exec implementation) to measure against here, so the property these
+// benchmarks actually exist to compare — round-trip *count*, which is what
+// motivated batching and parallelizing the scan in the first place — is
+// isolated with a fixed per-call cost instead of being lost in noise from
+// parseInstalledPackages' own (comparatively tiny) string-parsing cost.
+func simulatedExecRoundTrip() {
+	time.Sleep(2 * time.Millisecond)
+}
+
+// BenchmarkScanInstalledPackages_Serial models the pre-batching behavior:
+// one exec round-trip, followed by one parseInstalledPackages call, per
+// discovered app.
+func BenchmarkScanInstalledPackages_Serial(b *testing.B) {
+	records := benchmarkFixtureRecords(300)
+	o := &macos{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, rec := range records {
+			simulatedExecRoundTrip()
+			if _, _, err := o.parseInstalledPackages(rec); err != nil {
+				b.Fatalf("parseInstalledPackages() error = %s", err)
+			}
+		}
+	}
+}
+
+// BenchmarkScanInstalledPackages_Batched models the default scan path: every
+// app's record arrives in a single exec round-trip and is parsed in one call.
+func BenchmarkScanInstalledPackages_Batched(b *testing.B) {
+	stdout := strings.Join(benchmarkFixtureRecords(300), "\n")
+	o := &macos{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		simulatedExecRoundTrip()
+		if _, _, err := o.parseInstalledPackages(stdout); err != nil {
+			b.Fatalf("parseInstalledPackages() error = %s", err)
+		}
+	}
+}
+
+// BenchmarkScanInstalledPackages_Parallel models the local/controlling-master
+// scan path fanned out across a bounded worker pool so round-trips overlap
+// instead of queuing. scanInstalledPackagesParallel itself issues several
+// execs per app (one plutil extraction per key plus a find for helper apps);
+// here each app's work costs a single round-trip, same as Serial.
+func BenchmarkScanInstalledPackages_Parallel(b *testing.B) {
+	records := benchmarkFixtureRecords(300)
+	o := &macos{}
+	o.ServerInfo.ScanConcurrency = runtime.NumCPU()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, o.concurrency())
+		for _, rec := range records {
+			rec := rec
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				simulatedExecRoundTrip()
+				if _, _, err := o.parseInstalledPackages(rec); err != nil {
+					b.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}